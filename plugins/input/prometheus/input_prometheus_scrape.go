@@ -0,0 +1,314 @@
+// Copyright 2021 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alibaba/ilogtail"
+	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/util"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// BasicAuth holds HTTP basic-auth credentials for a scrape Target.
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+// TLSConfig controls how a Target's HTTPS endpoint is verified.
+type TLSConfig struct {
+	InsecureSkipVerify bool
+}
+
+// RelabelConfig is a regex-based keep/drop/replace rule applied to each scraped sample,
+// modeled after Prometheus' metric_relabel_configs.
+type RelabelConfig struct {
+	SourceLabels []string
+	Regex        string
+	Action       string // keep, drop, replace; defaults to replace
+	TargetLabel  string
+	Replacement  string
+
+	regex *regexp.Regexp
+}
+
+// Target is one Prometheus/OpenMetrics text exposition endpoint to scrape.
+type Target struct {
+	URL             string
+	Job             string
+	Labels          map[string]string
+	TLS             *TLSConfig
+	BearerTokenFile string
+	BasicAuth       *BasicAuth
+}
+
+// InputPrometheusScrape is a companion input to InputSystem (package systemv2): it reuses the
+// same common-labels machinery but, instead of reading local host metrics, scrapes a list of
+// user-configured HTTP /metrics endpoints on each Collect and forwards every series it finds.
+// This lets the agent ingest metrics from any node_exporter/cAdvisor/etcd endpoint without a
+// dedicated collector.
+type InputPrometheusScrape struct {
+	Targets              []Target
+	Timeout              int
+	HonorLabels          bool
+	MetricRelabelConfigs []RelabelConfig
+	Labels               map[string]string
+
+	context      ilogtail.Context
+	commonLabels helper.KeyValues
+	client       *http.Client
+}
+
+func (r *InputPrometheusScrape) Description() string {
+	return "Scrape Prometheus text exposition or OpenMetrics endpoints and forward their series as metrics."
+}
+
+func (r *InputPrometheusScrape) CommonInit(context ilogtail.Context) (int, error) {
+	r.context = context
+	r.commonLabels.Append("hostname", util.GetHostName())
+	r.commonLabels.Append("ip", util.GetIPAddress())
+	for key, val := range r.Labels {
+		r.commonLabels.Append(key, val)
+	}
+	r.commonLabels.Sort()
+
+	if r.Timeout <= 0 {
+		r.Timeout = 10
+	}
+	r.client = &http.Client{Timeout: time.Duration(r.Timeout) * time.Second}
+
+	for i := range r.MetricRelabelConfigs {
+		rc := &r.MetricRelabelConfigs[i]
+		if rc.Action == "" {
+			rc.Action = "replace"
+		}
+		regexSrc := rc.Regex
+		if regexSrc == "" {
+			regexSrc = "(.*)"
+		}
+		reg, err := regexp.Compile(regexSrc)
+		if err != nil {
+			logger.Error(r.context.GetRuntimeContext(), "COMPILE_REGEXP_ALARM", "err", err)
+			return 0, err
+		}
+		rc.regex = reg
+	}
+	return 0, nil
+}
+
+func (r *InputPrometheusScrape) Collect(collector ilogtail.Collector) error {
+	for _, target := range r.Targets {
+		r.scrapeTarget(collector, target)
+	}
+	return nil
+}
+
+func (r *InputPrometheusScrape) scrapeTarget(collector ilogtail.Collector, target Target) {
+	req, err := http.NewRequest(http.MethodGet, target.URL, nil)
+	if err != nil {
+		logger.Error(r.context.GetRuntimeContext(), "PROMETHEUS_SCRAPE_ALARM", "target", target.URL, "error", err)
+		return
+	}
+	req.Header.Set("Accept", "text/plain;version=0.0.4,application/openmetrics-text;version=1.0.0,*/*")
+	if target.BearerTokenFile != "" {
+		token, err := ioutil.ReadFile(target.BearerTokenFile)
+		if err != nil {
+			logger.Error(r.context.GetRuntimeContext(), "PROMETHEUS_SCRAPE_ALARM", "target", target.URL, "error", err)
+			return
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+	if target.BasicAuth != nil {
+		req.SetBasicAuth(target.BasicAuth.Username, target.BasicAuth.Password)
+	}
+
+	client := r.client
+	if target.TLS != nil {
+		client = &http.Client{
+			Timeout:   r.client.Timeout,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: target.TLS.InsecureSkipVerify}},
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		logger.Warning(r.context.GetRuntimeContext(), "PROMETHEUS_SCRAPE_ALARM", "target", target.URL, "error", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	collectTime := time.Now()
+	decoder := expfmt.NewDecoder(resp.Body, expfmt.ResponseFormat(resp.Header))
+	for {
+		var mf dto.MetricFamily
+		if err := decoder.Decode(&mf); err != nil {
+			break
+		}
+		r.emitFamily(collector, target, &mf, collectTime)
+	}
+}
+
+// sampleLabels merges the target's configured labels with the sample's own scraped labels,
+// applying honor_labels semantics: when HonorLabels is false (the default) a scraped label
+// colliding with a configured one is kept under an "exported_" prefix so the configured value
+// wins, matching Prometheus' own scrape_config behavior.
+func (r *InputPrometheusScrape) sampleLabels(target Target, pairs []*dto.LabelPair) map[string]string {
+	configured := map[string]string{"job": target.Job}
+	for k, v := range target.Labels {
+		configured[k] = v
+	}
+	labels := make(map[string]string, len(pairs)+len(configured))
+	for _, p := range pairs {
+		name := p.GetName()
+		if _, clash := configured[name]; clash && !r.HonorLabels {
+			name = "exported_" + name
+		}
+		labels[name] = p.GetValue()
+	}
+	for k, v := range configured {
+		if _, scraped := labels[k]; scraped && r.HonorLabels {
+			continue
+		}
+		labels[k] = v
+	}
+	return labels
+}
+
+// applyRelabel runs labels/name through MetricRelabelConfigs in order, returning the
+// (possibly rewritten) metric name and whether the sample survives.
+func (r *InputPrometheusScrape) applyRelabel(name string, labels map[string]string) (string, bool) {
+	for _, rc := range r.MetricRelabelConfigs {
+		var parts []string
+		if len(rc.SourceLabels) == 0 {
+			parts = []string{name}
+		} else {
+			for _, sl := range rc.SourceLabels {
+				if sl == "__name__" {
+					parts = append(parts, name)
+				} else {
+					parts = append(parts, labels[sl])
+				}
+			}
+		}
+		matched := rc.regex.MatchString(strings.Join(parts, ";"))
+		switch rc.Action {
+		case "drop":
+			if matched {
+				return name, false
+			}
+		case "keep":
+			if !matched {
+				return name, false
+			}
+		case "replace":
+			if matched && rc.TargetLabel != "" {
+				repl := rc.regex.ReplaceAllString(strings.Join(parts, ";"), rc.Replacement)
+				if rc.TargetLabel == "__name__" {
+					name = repl
+				} else {
+					labels[rc.TargetLabel] = repl
+				}
+			}
+		}
+	}
+	return name, true
+}
+
+func cloneLabels(labels map[string]string) map[string]string {
+	out := make(map[string]string, len(labels)+1)
+	for k, v := range labels {
+		out[k] = v
+	}
+	return out
+}
+
+func (r *InputPrometheusScrape) emitSample(collector ilogtail.Collector, name string, labels map[string]string, value float64, collectTime time.Time) {
+	finalName, keep := r.applyRelabel(name, labels)
+	if !keep {
+		return
+	}
+	kv := r.commonLabels.Clone()
+	for k, v := range labels {
+		kv.Append(k, v)
+	}
+	kv.Sort()
+	keys, vals := helper.MakeMetric(finalName, kv.String(), collectTime.UnixNano(), value)
+	collector.AddDataArray(nil, keys, vals, collectTime)
+}
+
+// emitFamily flattens counters/gauges/histograms/summaries into individual series, preserving
+// the `le`/`quantile` labels that histogram buckets and summary quantiles carry.
+func (r *InputPrometheusScrape) emitFamily(collector ilogtail.Collector, target Target, mf *dto.MetricFamily, collectTime time.Time) {
+	name := mf.GetName()
+	for _, m := range mf.GetMetric() {
+		labels := r.sampleLabels(target, m.GetLabel())
+		switch mf.GetType() {
+		case dto.MetricType_COUNTER:
+			r.emitSample(collector, name, labels, m.GetCounter().GetValue(), collectTime)
+		case dto.MetricType_GAUGE:
+			r.emitSample(collector, name, labels, m.GetGauge().GetValue(), collectTime)
+		case dto.MetricType_UNTYPED:
+			r.emitSample(collector, name, labels, m.GetUntyped().GetValue(), collectTime)
+		case dto.MetricType_HISTOGRAM:
+			h := m.GetHistogram()
+			hasInf := false
+			for _, b := range h.GetBucket() {
+				bucketLabels := cloneLabels(labels)
+				bucketLabels["le"] = strconv.FormatFloat(b.GetUpperBound(), 'g', -1, 64)
+				hasInf = hasInf || bucketLabels["le"] == "+Inf"
+				r.emitSample(collector, name+"_bucket", bucketLabels, float64(b.GetCumulativeCount()), collectTime)
+			}
+			// The text format always carries a +Inf bucket, but a decoded histogram may lack one
+			// (the protobuf exposition format omits it since it's always equal to the sample
+			// count); histogram_quantile requires it, so it is added from the sample count only
+			// when missing.
+			if !hasInf {
+				infLabels := cloneLabels(labels)
+				infLabels["le"] = "+Inf"
+				r.emitSample(collector, name+"_bucket", infLabels, float64(h.GetSampleCount()), collectTime)
+			}
+			r.emitSample(collector, name+"_sum", labels, h.GetSampleSum(), collectTime)
+			r.emitSample(collector, name+"_count", labels, float64(h.GetSampleCount()), collectTime)
+		case dto.MetricType_SUMMARY:
+			s := m.GetSummary()
+			for _, q := range s.GetQuantile() {
+				quantileLabels := cloneLabels(labels)
+				quantileLabels["quantile"] = strconv.FormatFloat(q.GetQuantile(), 'g', -1, 64)
+				r.emitSample(collector, name, quantileLabels, q.GetValue(), collectTime)
+			}
+			r.emitSample(collector, name+"_sum", labels, s.GetSampleSum(), collectTime)
+			r.emitSample(collector, name+"_count", labels, float64(s.GetSampleCount()), collectTime)
+		}
+	}
+}
+
+func init() {
+	ilogtail.MetricInputs["metric_prometheus_scrape"] = func() ilogtail.MetricInput {
+		return &InputPrometheusScrape{
+			Timeout: 10,
+		}
+	}
+}
@@ -20,10 +20,13 @@ import (
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/util"
 
+	"io/ioutil"
 	"math"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/prometheus/procfs"
@@ -35,11 +38,23 @@ import (
 	"github.com/shirou/gopsutil/net"
 )
 
+// cgroup version; once detected it is kept for the process lifetime since it cannot change at runtime.
+const (
+	cgroupUnknown = iota
+	cgroupV1
+	cgroupV2
+)
+
 // InputSystem plugin is modified with care, because two collect libs are used， which are procfs and gopsutil.
 // They are works well on the host machine. But on the linux virtual environment, they are different.
 // The procfs or read proc file system should mount the `logtail_host` path, more details please see `helper.mount_others.go`.
 // The gopsutil lib only support mount path with ENV config, more details please see `github.com/shirou/gopsutil/internal/common/common.go`.
 type InputSystem struct {
+	// Core now gates the load/uname sub-collectors (system_load*, system_boot_time); before
+	// the collector-registry refactor those metrics were emitted unconditionally and this
+	// field was read nowhere. The default factory below sets Core: true so default configs
+	// are unaffected, but a config that explicitly set Core: false as a (previously inert)
+	// no-op will now actually lose load/uname metrics.
 	Core              bool
 	CPU               bool
 	Mem               bool
@@ -49,17 +64,36 @@ type InputSystem struct {
 	TCP               bool
 	OpenFd            bool
 	CPUPercent        bool
+	Cgroup            bool
+	Pressure          bool
+	SMART             bool
+	PerCPU            bool
 	Disks             []string
 	NetInterfaces     []string
 	Labels            map[string]string
 	ExcludeDiskFsType string
 	ExcludeDiskPath   string
 
+	// Collectors, if non-empty, restricts Collect to exactly these named sub-collectors.
+	// DisabledCollectors removes names from whatever set is otherwise active. See
+	// RegisterCollector for how third-party collectors can be added to the registry.
+	Collectors         []string
+	DisabledCollectors []string
+
+	activeCollectors       []Collector
+	activeCollectorNames   []string
+	smartDisabled          bool
 	lastInfo               *host.InfoStat
 	lastCPUStat            cpu.TimesStat
 	lastCPUTime            time.Time
 	lastCPUTotal           float64
 	lastCPUBusy            float64
+	lastCPUStatPerCPU      []cpu.TimesStat
+	lastCPUTimePerCPU      time.Time
+	cgroupVersion          int
+	cgroupCPUQuotaCores    float64
+	lastCgroupCPUUsageUsec uint64
+	lastCgroupTime         time.Time
 	lastNetStat            net.IOCountersStat
 	lastNetStatAll         []net.IOCountersStat
 	lastNetTime            time.Time
@@ -107,6 +141,7 @@ func (r *InputSystem) CommonInit(context ilogtail.Context) (int, error) {
 	}
 	r.commonLabels.Sort()
 	r.commonLabelsStr = r.commonLabels.String()
+	r.buildActiveCollectors()
 	return 0, nil
 }
 
@@ -118,24 +153,30 @@ func (r *InputSystem) addMetric(collector ilogtail.Collector,
 	collector.AddDataArray(nil, keys, vals, r.collectTime)
 }
 
-func (r *InputSystem) CollectCore(collector ilogtail.Collector) {
-
-	// host info
-	if r.lastInfo == nil {
-		r.lastInfo, _ = host.Info()
+func (r *InputSystem) CollectLoad(collector ilogtail.Collector) error {
+	loadStat, err := load.Avg()
+	if err != nil {
+		return err
 	}
+	r.addMetric(collector, "system_load1", r.commonLabelsStr, loadStat.Load1)
+	r.addMetric(collector, "system_load5", r.commonLabelsStr, loadStat.Load5)
+	r.addMetric(collector, "system_load15", r.commonLabelsStr, loadStat.Load15)
+	return nil
+}
 
-	// load stat
-	loadStat, err := load.Avg()
-	if err == nil {
-		r.addMetric(collector, "system_load1", r.commonLabelsStr, loadStat.Load1)
-		r.addMetric(collector, "system_load5", r.commonLabelsStr, loadStat.Load5)
-		r.addMetric(collector, "system_load15", r.commonLabelsStr, loadStat.Load15)
+func (r *InputSystem) CollectUname(collector ilogtail.Collector) error {
+	if r.lastInfo == nil {
+		info, err := host.Info()
+		if err != nil {
+			return err
+		}
+		r.lastInfo = info
 	}
 	r.addMetric(collector, "system_boot_time", r.commonLabelsStr, float64(r.lastInfo.BootTime))
+	return nil
 }
 
-func (r *InputSystem) CollectCPU(collector ilogtail.Collector) {
+func (r *InputSystem) CollectCPU(collector ilogtail.Collector) error {
 	// cpu stat
 	cpuStat, err := cpu.Times(false)
 	cpuInfo, _ := cpu.Info()
@@ -151,16 +192,22 @@ func (r *InputSystem) CollectCPU(collector ilogtail.Collector) {
 
 		// cpushare计算
 		cpuShareFactor := 1.0
-		cpushareEnv := os.Getenv("SIGMA_CPU_REQUEST")
-		if len(cpushareEnv) > 0 {
-			cpuRequest, err := strconv.Atoi(cpushareEnv)
-			if err != nil || cpuRequest <= 0 || ncpus == 0 {
-				logger.Error(r.context.GetRuntimeContext(), "GET_SIGMA_ENV_ERROR", "get sigma env failed",
-					"error", err,
-					"ncpus", ncpus,
-					"SIGMA_CPU_REQUEST", cpushareEnv)
-			} else {
-				cpuShareFactor = float64(ncpus) / (float64(cpuRequest) / 1000.)
+		if r.Cgroup && r.cgroupCPUQuotaCores > 0 {
+			// a cgroup CPU quota is authoritative over the container's effective share,
+			// so it takes precedence over the legacy SIGMA_CPU_REQUEST env hack below.
+			cpuShareFactor = float64(ncpus) / r.cgroupCPUQuotaCores
+		} else {
+			cpushareEnv := os.Getenv("SIGMA_CPU_REQUEST")
+			if len(cpushareEnv) > 0 {
+				cpuRequest, err := strconv.Atoi(cpushareEnv)
+				if err != nil || cpuRequest <= 0 || ncpus == 0 {
+					logger.Error(r.context.GetRuntimeContext(), "GET_SIGMA_ENV_ERROR", "get sigma env failed",
+						"error", err,
+						"ncpus", ncpus,
+						"SIGMA_CPU_REQUEST", cpushareEnv)
+				} else {
+					cpuShareFactor = float64(ncpus) / (float64(cpuRequest) / 1000.)
+				}
 			}
 		}
 
@@ -183,24 +230,338 @@ func (r *InputSystem) CollectCPU(collector ilogtail.Collector) {
 		r.lastCPUBusy = cpuBusy
 		r.lastCPUTotal = cpuTotal
 	}
+
+	if r.PerCPU {
+		deltas := r.collectPerCPU(collector)
+		if deltas != nil {
+			r.collectNUMA(collector, deltas)
+		}
+	}
+	return err
+}
+
+func clampNonNegative(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	return v
+}
+
+// cpuDelta is the per-core busy/total tick delta computed for one scrape interval, kept
+// around so collectNUMA can re-aggregate it per NUMA node without re-reading /proc/stat.
+type cpuDelta struct {
+	busy  float64
+	total float64
+}
+
+// collectOneCPU emits cpu_util/cpu_user_util/cpu_sys_util/cpu_wait_util for a single logical
+// CPU and returns its busy/total tick delta for NUMA aggregation.
+func (r *InputSystem) collectOneCPU(collector ilogtail.Collector, cpuName string, last, now *cpu.TimesStat) cpuDelta {
+	newLabels := r.commonLabels.Clone()
+	newLabels.Append("cpu", strings.TrimPrefix(cpuName, "cpu"))
+	newLabels.Sort()
+	labels := newLabels.String()
+
+	userD := clampNonNegative(now.User - last.User)
+	sysD := clampNonNegative(now.System - last.System)
+	waitD := clampNonNegative(now.Iowait - last.Iowait)
+	busy := userD + sysD +
+		clampNonNegative(now.Nice-last.Nice) + clampNonNegative(now.Irq-last.Irq) +
+		clampNonNegative(now.Softirq-last.Softirq) + clampNonNegative(now.Steal-last.Steal) +
+		clampNonNegative(now.Guest-last.Guest) + clampNonNegative(now.GuestNice-last.GuestNice)
+	total := busy + waitD + clampNonNegative(now.Idle-last.Idle)
+
+	if total > 0 {
+		r.addMetric(collector, "cpu_util", labels, 100*busy/total)
+		r.addMetric(collector, "cpu_user_util", labels, 100*userD/total)
+		r.addMetric(collector, "cpu_sys_util", labels, 100*sysD/total)
+		r.addMetric(collector, "cpu_wait_util", labels, 100*waitD/total)
+	}
+	return cpuDelta{busy: busy, total: total}
+}
+
+// collectPerCPU emits per-core utilization when PerCPU is enabled. Hotplug (the CPU count
+// changing between scrapes) invalidates the previous baseline, so that round is skipped
+// rather than diffed against stats for a different set of cores.
+func (r *InputSystem) collectPerCPU(collector ilogtail.Collector) []cpuDelta {
+	perCPUStat, err := cpu.Times(true)
+	if err != nil || len(perCPUStat) == 0 {
+		return nil
+	}
+	nowTime := time.Now()
+	if len(r.lastCPUStatPerCPU) != len(perCPUStat) {
+		r.lastCPUStatPerCPU = perCPUStat
+		r.lastCPUTimePerCPU = nowTime
+		return nil
+	}
+
+	var deltas []cpuDelta
+	if !r.lastCPUTimePerCPU.IsZero() {
+		deltas = make([]cpuDelta, len(perCPUStat))
+		for i := range perCPUStat {
+			deltas[i] = r.collectOneCPU(collector, perCPUStat[i].CPU, &r.lastCPUStatPerCPU[i], &perCPUStat[i])
+		}
+	}
+	r.lastCPUStatPerCPU = perCPUStat
+	r.lastCPUTimePerCPU = nowTime
+	return deltas
+}
+
+// parseCPUList expands a Linux cpulist ("0-3,8,10-11") into individual logical CPU indices.
+func parseCPUList(s string) []int {
+	var ids []int
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if idx := strings.IndexByte(part, '-'); idx >= 0 {
+			start, err1 := strconv.Atoi(part[:idx])
+			end, err2 := strconv.Atoi(part[idx+1:])
+			if err1 == nil && err2 == nil {
+				for i := start; i <= end; i++ {
+					ids = append(ids, i)
+				}
+			}
+			continue
+		}
+		if v, err := strconv.Atoi(part); err == nil {
+			ids = append(ids, v)
+		}
+	}
+	return ids
+}
+
+// parseNUMAMeminfo reads a node's meminfo file ("Node 0 MemTotal: NNN kB" lines).
+func parseNUMAMeminfo(path string) (total, free, used int64, ok bool) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		val, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			continue
+		}
+		val *= 1024
+		switch strings.TrimSuffix(fields[2], ":") {
+		case "MemTotal":
+			total = val
+		case "MemFree":
+			free = val
+		case "MemUsed":
+			used = val
+		}
+	}
+	if used == 0 {
+		used = total - free
+	}
+	return total, free, used, true
+}
+
+func (r *InputSystem) collectOneNUMANode(collector ilogtail.Collector, nodeID, nodeDir string, deltas []cpuDelta) {
+	newLabels := r.commonLabels.Clone()
+	newLabels.Append("node", nodeID)
+	newLabels.Sort()
+	labels := newLabels.String()
+
+	if cpulist, err := ioutil.ReadFile(filepath.Join(nodeDir, "cpulist")); err == nil {
+		var busy, total float64
+		for _, id := range parseCPUList(strings.TrimSpace(string(cpulist))) {
+			if id >= 0 && id < len(deltas) {
+				busy += deltas[id].busy
+				total += deltas[id].total
+			}
+		}
+		if total > 0 {
+			r.addMetric(collector, "numa_cpu_util", labels, 100*busy/total)
+		}
+	}
+
+	if total, free, used, ok := parseNUMAMeminfo(filepath.Join(nodeDir, "meminfo")); ok {
+		r.addMetric(collector, "numa_mem_total", labels, float64(total))
+		r.addMetric(collector, "numa_mem_free", labels, float64(free))
+		r.addMetric(collector, "numa_mem_used", labels, float64(used))
+	}
 }
 
-func (r *InputSystem) CollectMem(collector ilogtail.Collector) {
+// collectNUMA aggregates the per-core deltas from collectPerCPU into per-NUMA-node
+// utilization and reads each node's own meminfo, when /sys/devices/system/node is present.
+func (r *InputSystem) collectNUMA(collector ilogtail.Collector, deltas []cpuDelta) {
+	base := util.GetMountedFilePath("/sys/devices/system/node")
+	entries, err := ioutil.ReadDir(base)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), "node") {
+			continue
+		}
+		nodeID := strings.TrimPrefix(entry.Name(), "node")
+		if _, err := strconv.Atoi(nodeID); err != nil {
+			continue
+		}
+		r.collectOneNUMANode(collector, nodeID, filepath.Join(base, entry.Name()), deltas)
+	}
+}
+
+// detectCgroupVersion probes the mounted cgroup hierarchy and caches the result once a version
+// is found; while neither hierarchy is present it probes again on every call.
+// cgroup v2 is unified and exposes cgroup.controllers at the root, v1 splits each
+// controller (cpu, memory, ...) into its own hierarchy.
+func (r *InputSystem) detectCgroupVersion() int {
+	if r.cgroupVersion != cgroupUnknown {
+		return r.cgroupVersion
+	}
+	if _, err := os.Stat(util.GetMountedFilePath("/sys/fs/cgroup/cgroup.controllers")); err == nil {
+		r.cgroupVersion = cgroupV2
+	} else if _, err := os.Stat(util.GetMountedFilePath("/sys/fs/cgroup/cpu")); err == nil {
+		r.cgroupVersion = cgroupV1
+	}
+	return r.cgroupVersion
+}
+
+func readCgroupFileInt64(path string) (int64, bool) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	val, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return val, true
+}
+
+func readCgroupKeyedFile(path string) map[string]int64 {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	res := make(map[string]int64)
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		if val, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+			res[fields[0]] = val
+		}
+	}
+	return res
+}
+
+// cgroupCPUQuotaAndUsage returns the configured quota expressed in number of cpus (0 if unlimited)
+// and the cumulative CPU usage in microseconds.
+func (r *InputSystem) cgroupCPUQuotaAndUsage(version int) (quotaCores float64, usageUsec uint64, throttledNs uint64, throttledPeriods int64) {
+	switch version {
+	case cgroupV2:
+		if data, err := ioutil.ReadFile(util.GetMountedFilePath("/sys/fs/cgroup/cpu.max")); err == nil {
+			fields := strings.Fields(string(data))
+			if len(fields) == 2 && fields[0] != "max" {
+				quota, qerr := strconv.ParseFloat(fields[0], 64)
+				period, perr := strconv.ParseFloat(fields[1], 64)
+				if qerr == nil && perr == nil && period > 0 {
+					quotaCores = quota / period
+				}
+			}
+		}
+		stat := readCgroupKeyedFile(util.GetMountedFilePath("/sys/fs/cgroup/cpu.stat"))
+		usageUsec = uint64(stat["usage_usec"])
+		throttledNs = uint64(stat["throttled_usec"]) * uint64(time.Microsecond)
+		throttledPeriods = stat["nr_throttled"]
+	case cgroupV1:
+		quotaUs, okQuota := readCgroupFileInt64(util.GetMountedFilePath("/sys/fs/cgroup/cpu/cpu.cfs_quota_us"))
+		periodUs, okPeriod := readCgroupFileInt64(util.GetMountedFilePath("/sys/fs/cgroup/cpu/cpu.cfs_period_us"))
+		if okQuota && okPeriod && quotaUs > 0 && periodUs > 0 {
+			quotaCores = float64(quotaUs) / float64(periodUs)
+		}
+		if usage, ok := readCgroupFileInt64(util.GetMountedFilePath("/sys/fs/cgroup/cpuacct/cpuacct.usage")); ok {
+			usageUsec = uint64(usage) / uint64(time.Microsecond)
+		}
+		stat := readCgroupKeyedFile(util.GetMountedFilePath("/sys/fs/cgroup/cpu/cpu.stat"))
+		throttledNs = uint64(stat["throttled_time"])
+		throttledPeriods = stat["nr_throttled"]
+	}
+	return
+}
+
+// CollectCgroup reads container-level CPU/memory limits and usage directly from the
+// cgroup v1 or v2 hierarchy, which is more accurate than the SIGMA_CPU_REQUEST env hack
+// because it also reflects throttling and the live memory working set.
+func (r *InputSystem) CollectCgroup(collector ilogtail.Collector) {
+	version := r.detectCgroupVersion()
+	if version == cgroupUnknown {
+		return
+	}
+
+	quotaCores, usageUsec, throttledNs, throttledPeriods := r.cgroupCPUQuotaAndUsage(version)
+	r.cgroupCPUQuotaCores = quotaCores
+
+	nowTime := time.Now()
+	if !r.lastCgroupTime.IsZero() && usageUsec >= r.lastCgroupCPUUsageUsec {
+		elapsedUsec := float64(nowTime.Sub(r.lastCgroupTime)) / float64(time.Microsecond)
+		if elapsedUsec > 0 && quotaCores > 0 {
+			deltaUsageUsec := float64(usageUsec - r.lastCgroupCPUUsageUsec)
+			r.addMetric(collector, "container_cpu_util", r.commonLabelsStr, 100*deltaUsageUsec/(elapsedUsec*quotaCores))
+		}
+	}
+	// Both throttled metrics are cumulative counters, matching node_exporter's
+	// container_cpu_cfs_throttled_seconds_total/container_cpu_cfs_throttled_periods_total: a
+	// rate() over either behaves the same way, rather than mixing a pre-differenced gauge with
+	// a raw counter.
+	r.addMetric(collector, "container_cpu_throttled_time", r.commonLabelsStr, float64(throttledNs))
+	r.addMetric(collector, "container_cpu_throttled_periods", r.commonLabelsStr, float64(throttledPeriods))
+	r.lastCgroupCPUUsageUsec = usageUsec
+	r.lastCgroupTime = nowTime
+
+	var memLimit, memUsage int64
+	var okLimit, okUsage bool
+	switch version {
+	case cgroupV2:
+		if data, err := ioutil.ReadFile(util.GetMountedFilePath("/sys/fs/cgroup/memory.max")); err == nil {
+			if s := strings.TrimSpace(string(data)); s != "max" {
+				if val, err := strconv.ParseInt(s, 10, 64); err == nil {
+					memLimit, okLimit = val, true
+				}
+			}
+		}
+		memUsage, okUsage = readCgroupFileInt64(util.GetMountedFilePath("/sys/fs/cgroup/memory.current"))
+	case cgroupV1:
+		memLimit, okLimit = readCgroupFileInt64(util.GetMountedFilePath("/sys/fs/cgroup/memory/memory.limit_in_bytes"))
+		memUsage, okUsage = readCgroupFileInt64(util.GetMountedFilePath("/sys/fs/cgroup/memory/memory.usage_in_bytes"))
+	}
+	if okUsage {
+		r.addMetric(collector, "container_mem_working_set", r.commonLabelsStr, float64(memUsage))
+		if okLimit && memLimit > 0 {
+			r.addMetric(collector, "container_mem_util", r.commonLabelsStr, 100*float64(memUsage)/float64(memLimit))
+		}
+	}
+}
+
+func (r *InputSystem) CollectMem(collector ilogtail.Collector) error {
 	// mem stat
 	memStat, err := mem.VirtualMemory()
-	if err == nil {
-		r.addMetric(collector, "mem_util", r.commonLabelsStr, memStat.UsedPercent)
-		r.addMetric(collector, "mem_cache", r.commonLabelsStr, float64(memStat.Cached))
-		r.addMetric(collector, "mem_free", r.commonLabelsStr, float64(memStat.Free))
-		r.addMetric(collector, "mem_available", r.commonLabelsStr, float64(memStat.Available))
-		r.addMetric(collector, "mem_used", r.commonLabelsStr, float64(memStat.Used))
-		r.addMetric(collector, "mem_total", r.commonLabelsStr, float64(memStat.Total))
+	if err != nil {
+		return err
 	}
+	r.addMetric(collector, "mem_util", r.commonLabelsStr, memStat.UsedPercent)
+	r.addMetric(collector, "mem_cache", r.commonLabelsStr, float64(memStat.Cached))
+	r.addMetric(collector, "mem_free", r.commonLabelsStr, float64(memStat.Free))
+	r.addMetric(collector, "mem_available", r.commonLabelsStr, float64(memStat.Available))
+	r.addMetric(collector, "mem_used", r.commonLabelsStr, float64(memStat.Used))
+	r.addMetric(collector, "mem_total", r.commonLabelsStr, float64(memStat.Total))
 
 	swapStat, err := mem.SwapMemory()
-	if err == nil {
-		r.addMetric(collector, "mem_swap_util", r.commonLabelsStr, swapStat.UsedPercent)
+	if err != nil {
+		return err
 	}
+	r.addMetric(collector, "mem_swap_util", r.commonLabelsStr, swapStat.UsedPercent)
+	return nil
 }
 
 func (r *InputSystem) collectOneDisk(collector ilogtail.Collector, name string, timeDeltaSec float64, last, now *disk.IOCountersStat) {
@@ -227,42 +588,42 @@ func (r *InputSystem) collectOneDisk(collector ilogtail.Collector, name string,
 	}
 }
 
-func (r *InputSystem) CollectDisk(collector ilogtail.Collector) {
-	r.CollectDiskUsage(collector)
-
+func (r *InputSystem) CollectDiskIO(collector ilogtail.Collector) error {
 	// disk stat
 	allIoCounters, err := disk.IOCounters(r.Disks...)
-	if err == nil {
-		totalIoCount := disk.IOCountersStat{}
-		for _, ioCount := range allIoCounters {
-			totalIoCount.ReadBytes += ioCount.ReadBytes
-			totalIoCount.WriteBytes += ioCount.WriteBytes
-			totalIoCount.ReadCount += ioCount.ReadCount
-			totalIoCount.WriteCount += ioCount.WriteCount
-			totalIoCount.ReadTime += ioCount.ReadTime
-			totalIoCount.WriteTime += ioCount.WriteTime
-			totalIoCount.IopsInProgress += ioCount.IopsInProgress
-			totalIoCount.IoTime += ioCount.IoTime
+	if err != nil {
+		return err
+	}
+	totalIoCount := disk.IOCountersStat{}
+	for _, ioCount := range allIoCounters {
+		totalIoCount.ReadBytes += ioCount.ReadBytes
+		totalIoCount.WriteBytes += ioCount.WriteBytes
+		totalIoCount.ReadCount += ioCount.ReadCount
+		totalIoCount.WriteCount += ioCount.WriteCount
+		totalIoCount.ReadTime += ioCount.ReadTime
+		totalIoCount.WriteTime += ioCount.WriteTime
+		totalIoCount.IopsInProgress += ioCount.IopsInProgress
+		totalIoCount.IoTime += ioCount.IoTime
 
-		}
+	}
 
-		nowTime := time.Now()
+	nowTime := time.Now()
 
-		if !r.lastDiskTime.IsZero() {
-			timeDeltaSec := float64(nowTime.Sub(r.lastDiskTime)) / float64(time.Second)
-			r.collectOneDisk(collector, "total", timeDeltaSec, &r.lastDiskStat, &totalIoCount)
-			for key, ioCount := range allIoCounters {
-				if lastIOCount, ok := r.lastDiskStatAll[key]; ok {
-					count := ioCount
-					r.collectOneDisk(collector, key, timeDeltaSec, &lastIOCount, &count)
-				}
+	if !r.lastDiskTime.IsZero() {
+		timeDeltaSec := float64(nowTime.Sub(r.lastDiskTime)) / float64(time.Second)
+		r.collectOneDisk(collector, "total", timeDeltaSec, &r.lastDiskStat, &totalIoCount)
+		for key, ioCount := range allIoCounters {
+			if lastIOCount, ok := r.lastDiskStatAll[key]; ok {
+				count := ioCount
+				r.collectOneDisk(collector, key, timeDeltaSec, &lastIOCount, &count)
 			}
 		}
-
-		r.lastDiskTime = nowTime
-		r.lastDiskStat = totalIoCount
-		r.lastDiskStatAll = allIoCounters
 	}
+
+	r.lastDiskTime = nowTime
+	r.lastDiskStat = totalIoCount
+	r.lastDiskStatAll = allIoCounters
+	return nil
 }
 
 func (r *InputSystem) collectOneNet(collector ilogtail.Collector, name string, timeDeltaSec float64, last, now *net.IOCountersStat) {
@@ -293,9 +654,12 @@ func (r *InputSystem) collectOneNet(collector ilogtail.Collector, name string, t
 	}
 }
 
-func (r *InputSystem) CollectNet(collector ilogtail.Collector) {
+func (r *InputSystem) CollectNet(collector ilogtail.Collector) error {
 	netIoStatAll, err := net.IOCounters(true)
-	if err == nil && len(netIoStatAll) > 0 {
+	if err != nil {
+		return err
+	}
+	if len(netIoStatAll) > 0 {
 		netIoStatTotal := net.IOCountersStat{}
 
 		for _, netIoStat := range netIoStatAll {
@@ -328,11 +692,15 @@ func (r *InputSystem) CollectNet(collector ilogtail.Collector) {
 		r.lastNetStat = netIoStatTotal
 		r.lastNetStatAll = netIoStatAll
 	}
+	return nil
 }
 
-func (r *InputSystem) CollectProtocol(collector ilogtail.Collector) {
+func (r *InputSystem) CollectProtocol(collector ilogtail.Collector) error {
 	protoCounterStats, err := net.ProtoCounters([]string{})
-	if err == nil && len(protoCounterStats) > 0 {
+	if err != nil {
+		return err
+	}
+	if len(protoCounterStats) > 0 {
 
 		nowTime := time.Now()
 		retransSegField := "RetransSegs"
@@ -365,36 +733,284 @@ func (r *InputSystem) CollectProtocol(collector ilogtail.Collector) {
 		r.lastProtoTime = nowTime
 		r.lastProtoAll = protoCounterStats
 	}
+	return nil
 }
 
-func (r *InputSystem) Collect(collector ilogtail.Collector) error {
-	r.collectTime = time.Now()
-	r.CollectCore(collector)
-	if r.CPU {
-		r.CollectCPU(collector)
+// tcpStateNames maps the hex connection state in /proc/net/tcp{,6} to its tcp_states.h name.
+var tcpStateNames = map[string]string{
+	"01": "ESTABLISHED",
+	"02": "SYN_SENT",
+	"03": "SYN_RECV",
+	"04": "FIN_WAIT1",
+	"05": "FIN_WAIT2",
+	"06": "TIME_WAIT",
+	"07": "CLOSE",
+	"08": "CLOSE_WAIT",
+	"09": "LAST_ACK",
+	"0A": "LISTEN",
+	"0B": "CLOSING",
+}
+
+// tallyTCPStates reads one of /proc/net/tcp or /proc/net/tcp6 and adds a count per
+// connection state to counts. Missing files (e.g. IPv6 disabled) are silently skipped.
+func tallyTCPStates(path string, counts map[string]int) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
 	}
-	if r.Mem {
-		r.CollectMem(collector)
+	lines := strings.Split(string(data), "\n")
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		name, ok := tcpStateNames[strings.ToUpper(fields[3])]
+		if !ok {
+			name = "UNKNOWN"
+		}
+		counts[name]++
 	}
-	if r.Disk {
-		r.CollectDisk(collector)
+}
+
+// CollectTCPConnStats tallies TCP connections by state from /proc/net/tcp{,6} and, where
+// available, enriches them with per-socket RTT/congestion-window stats gathered via netlink
+// SOCK_DIAG (see tcp_diag_linux.go); on platforms without that support it only emits the
+// per-state connection counts.
+func (r *InputSystem) CollectTCPConnStats(collector ilogtail.Collector) {
+	counts := map[string]int{}
+	tallyTCPStates(util.GetMountedFilePath("/proc/net/tcp"), counts)
+	tallyTCPStates(util.GetMountedFilePath("/proc/net/tcp6"), counts)
+	for state, count := range counts {
+		newLabels := r.commonLabels.Clone()
+		newLabels.Append("state", state)
+		newLabels.Sort()
+		r.addMetric(collector, "net_tcp_connections", newLabels.String(), float64(count))
 	}
-	if r.Net {
-		r.CollectNet(collector)
+	r.collectTCPSocketDiagMetrics(collector)
+}
+
+var pressureAvgFieldRegex = regexp.MustCompile(`avg10=([0-9.]+)\s+avg60=([0-9.]+)\s+avg300=([0-9.]+)\s+total=([0-9]+)`)
+
+// collectOnePressureLine parses a single `some`/`full` line of /proc/pressure/<resource> and
+// emits its avgN gauges plus a total microseconds counter.
+func (r *InputSystem) collectOnePressureLine(collector ilogtail.Collector, resource, kind, line string) {
+	m := pressureAvgFieldRegex.FindStringSubmatch(line)
+	if m == nil {
+		return
+	}
+	avg10, err1 := strconv.ParseFloat(m[1], 64)
+	avg60, err2 := strconv.ParseFloat(m[2], 64)
+	avg300, err3 := strconv.ParseFloat(m[3], 64)
+	total, err4 := strconv.ParseFloat(m[4], 64)
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+		return
+	}
+	prefix := "pressure_" + resource + "_" + kind
+	r.addMetric(collector, prefix+"_avg10", r.commonLabelsStr, avg10)
+	r.addMetric(collector, prefix+"_avg60", r.commonLabelsStr, avg60)
+	r.addMetric(collector, prefix+"_avg300", r.commonLabelsStr, avg300)
+	r.addMetric(collector, prefix+"_total", r.commonLabelsStr, total)
+}
+
+// CollectPressure reads Linux Pressure Stall Information from /proc/pressure/{cpu,memory,io}.
+// The files don't exist on kernels older than 4.20 or when PSI is disabled, and cpu has no
+// `full` line on older kernels either; both cases are silently skipped.
+func (r *InputSystem) CollectPressure(collector ilogtail.Collector) {
+	for _, resource := range []string{"cpu", "memory", "io"} {
+		data, err := ioutil.ReadFile(util.GetMountedFilePath("/proc/pressure/" + resource))
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			switch {
+			case strings.HasPrefix(line, "some"):
+				r.collectOnePressureLine(collector, resource, "some", line)
+			case strings.HasPrefix(line, "full"):
+				r.collectOnePressureLine(collector, resource, "full", line)
+			}
+		}
+	}
+}
+
+// SMART data collection (smartCandidateDisks, CollectSMART, ...) lives in smart_collector.go,
+// gated behind the "smart" build tag: github.com/anatol/smart.go is an unpinned dependency in
+// this repo snapshot, and a niche, CAP_SYS_RAWIO-gated feature shouldn't be able to break the
+// build for every other collector in this package. smart_collector_stub.go supplies the "smart"
+// registry entry for ordinary (non-"smart"-tagged) builds.
+
+// buildActiveCollectors resolves the registry against r.Collectors/r.DisabledCollectors (and,
+// for backward compatibility, the legacy per-metric bool fields) into the ordered set of
+// sub-collectors that Collect will run.
+func (r *InputSystem) buildActiveCollectors() {
+	allow := map[string]bool{}
+	for _, name := range r.Collectors {
+		allow[name] = true
 	}
-	if r.Protocol {
-		r.CollectProtocol(collector)
+	deny := map[string]bool{}
+	for _, name := range r.DisabledCollectors {
+		deny[name] = true
 	}
-	if r.OpenFd {
-		r.CollectOpenFD(collector)
+
+	r.activeCollectors = nil
+	r.activeCollectorNames = nil
+	for _, name := range collectorOrder {
+		rc := collectorRegistry[name]
+		enabled := rc.defaultEnabled
+		if legacy, ok := r.legacyCollectorEnabled(name); ok {
+			enabled = legacy
+		}
+		if len(r.Collectors) > 0 {
+			enabled = allow[name]
+		}
+		if deny[name] {
+			enabled = false
+		}
+		if !enabled {
+			continue
+		}
+		r.activeCollectorNames = append(r.activeCollectorNames, name)
+		r.activeCollectors = append(r.activeCollectors, rc.factory(r))
+	}
+}
+
+// legacyCollectorEnabled lets the pre-existing per-metric bool fields keep driving their
+// collector's enablement so that configs written before the registry existed still work.
+func (r *InputSystem) legacyCollectorEnabled(name string) (enabled bool, hasLegacy bool) {
+	switch name {
+	case "cpu":
+		return r.CPU, true
+	case "mem":
+		return r.Mem, true
+	case "diskio", "diskusage":
+		return r.Disk, true
+	case "net":
+		return r.Net, true
+	case "protocol":
+		return r.Protocol, true
+	case "openfd":
+		return r.OpenFd, true
+	case "load", "uname":
+		return r.Core, true
+	case "pressure":
+		return r.Pressure, true
+	case "smart":
+		return r.SMART, true
+	case "tcp":
+		return r.TCP, true
+	case "cgroup":
+		return r.Cgroup, true
+	}
+	return false, false
+}
+
+func (r *InputSystem) Collect(collector ilogtail.Collector) error {
+	r.collectTime = time.Now()
+	for i, c := range r.activeCollectors {
+		name := r.activeCollectorNames[i]
+		start := time.Now()
+		err := c.Update(collector)
+
+		newLabels := r.commonLabels.Clone()
+		newLabels.Append("collector", name)
+		newLabels.Sort()
+		labels := newLabels.String()
+		r.addMetric(collector, "scrape_collector_duration_seconds", labels, time.Since(start).Seconds())
+		if err != nil {
+			logger.Error(r.context.GetRuntimeContext(), "COLLECT_ALARM", "collector", name, "error", err)
+			r.addMetric(collector, "scrape_collector_success", labels, 0)
+			continue
+		}
+		r.addMetric(collector, "scrape_collector_success", labels, 1)
 	}
 	return nil
 }
 
+// Collector is implemented by every pluggable sub-collector registered through
+// RegisterCollector. Splitting Collect into named, independently togglable sub-collectors
+// mirrors the node_exporter architecture and lets third-party plugins add collectors to
+// systemv2 without editing this package.
+type Collector interface {
+	Update(collector ilogtail.Collector) error
+}
+
+// funcCollector adapts a plain collect call into the Collector interface for the built-in
+// collectors below, which all just delegate to an existing CollectXxx method.
+type funcCollector func(collector ilogtail.Collector) error
+
+func (f funcCollector) Update(collector ilogtail.Collector) error {
+	return f(collector)
+}
+
+type registeredCollector struct {
+	defaultEnabled bool
+	factory        func(r *InputSystem) Collector
+}
+
+var collectorRegistry = map[string]registeredCollector{}
+var collectorOrder []string
+
+// RegisterCollector registers a named sub-collector factory, typically from an init() in
+// this package or in a third-party plugin that imports it. defaultEnabled controls whether
+// the collector runs when the InputSystem's Collectors allow-list is empty; for a built-in
+// collector that has a legacy per-metric bool field (see legacyCollectorEnabled), that field
+// decides instead.
+func RegisterCollector(name string, defaultEnabled bool, factory func(r *InputSystem) Collector) {
+	if _, exists := collectorRegistry[name]; !exists {
+		collectorOrder = append(collectorOrder, name)
+	}
+	collectorRegistry[name] = registeredCollector{defaultEnabled: defaultEnabled, factory: factory}
+}
+
+func init() {
+	RegisterCollector("load", true, func(r *InputSystem) Collector {
+		return funcCollector(r.CollectLoad)
+	})
+	RegisterCollector("uname", true, func(r *InputSystem) Collector {
+		return funcCollector(r.CollectUname)
+	})
+	// registered ahead of cpu: CollectCPU reads r.cgroupCPUQuotaCores, which CollectCgroup
+	// computes for this round, to make the container's effective share override the legacy
+	// SIGMA_CPU_REQUEST-based cpuShareFactor.
+	RegisterCollector("cgroup", false, func(r *InputSystem) Collector {
+		return funcCollector(func(collector ilogtail.Collector) error { r.CollectCgroup(collector); return nil })
+	})
+	RegisterCollector("cpu", true, func(r *InputSystem) Collector {
+		return funcCollector(r.CollectCPU)
+	})
+	RegisterCollector("mem", true, func(r *InputSystem) Collector {
+		return funcCollector(r.CollectMem)
+	})
+	RegisterCollector("diskio", true, func(r *InputSystem) Collector {
+		return funcCollector(r.CollectDiskIO)
+	})
+	RegisterCollector("diskusage", true, func(r *InputSystem) Collector {
+		return funcCollector(func(collector ilogtail.Collector) error { r.CollectDiskUsage(collector); return nil })
+	})
+	RegisterCollector("net", true, func(r *InputSystem) Collector {
+		return funcCollector(r.CollectNet)
+	})
+	RegisterCollector("protocol", true, func(r *InputSystem) Collector {
+		return funcCollector(r.CollectProtocol)
+	})
+	RegisterCollector("openfd", true, func(r *InputSystem) Collector {
+		return funcCollector(func(collector ilogtail.Collector) error { r.CollectOpenFD(collector); return nil })
+	})
+	RegisterCollector("pressure", true, func(r *InputSystem) Collector {
+		return funcCollector(func(collector ilogtail.Collector) error { r.CollectPressure(collector); return nil })
+	})
+	// "smart" is registered from smart_collector.go / smart_collector_stub.go depending on the
+	// "smart" build tag, not here.
+	RegisterCollector("tcp", false, func(r *InputSystem) Collector {
+		return funcCollector(func(collector ilogtail.Collector) error { r.CollectTCPConnStats(collector); return nil })
+	})
+}
+
 func init() {
 	ilogtail.MetricInputs["metric_system_v2"] = func() ilogtail.MetricInput {
 		return &InputSystem{
 			CPUPercent:        true,
+			Core:              true,
+			Pressure:          true,
 			CPU:               true,
 			Mem:               true,
 			Disk:              true,
@@ -0,0 +1,33 @@
+// Copyright 2021 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !smart
+// +build !smart
+
+package systemv2
+
+import "github.com/alibaba/ilogtail"
+
+// Ordinary builds (no "smart" build tag) don't pull in github.com/anatol/smart.go at all, so a
+// SMART field mismatch or an unpinned version of that dependency can never break the rest of
+// this package. Enabling the "smart" collector in config still requires building with
+// `-tags smart`, which picks up the real implementation from smart_collector.go instead.
+func (r *InputSystem) CollectSMART(collector ilogtail.Collector) {
+}
+
+func init() {
+	RegisterCollector("smart", false, func(r *InputSystem) Collector {
+		return funcCollector(func(collector ilogtail.Collector) error { r.CollectSMART(collector); return nil })
+	})
+}
@@ -0,0 +1,206 @@
+// Copyright 2021 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package systemv2
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+
+	"github.com/alibaba/ilogtail"
+
+	"golang.org/x/sys/unix"
+)
+
+// This file gathers per-socket TCP stats (RTT, congestion window, retransmits) through a
+// minimal hand-rolled AF_NETLINK/NETLINK_SOCK_DIAG client, avoiding a dependency on a full
+// netlink library or shelling out to `ss`. It implements just enough of inet_diag(7) to issue
+// a SOCK_DIAG_BY_FAMILY dump with the INET_DIAG_INFO extension and read back each socket's
+// embedded struct tcp_info.
+
+const (
+	sockDiagByFamily  = 20 // linux/sock_diag.h: SOCK_DIAG_BY_FAMILY
+	inetDiagInfoAttr  = 2  // linux/inet_diag.h: INET_DIAG_INFO
+	inetDiagInfoExt   = 1 << (inetDiagInfoAttr - 1)
+	inetDiagAllStates = 0xFFF // every TCP state, see linux/inet_diag.h idiag_states
+	inetDiagReqV2Len  = 56    // sizeof(struct inet_diag_req_v2)
+	inetDiagMsgLen    = 72    // sizeof(struct inet_diag_msg)
+
+	// byte offsets of the fields we need within struct tcp_info (linux/tcp.h); the struct has
+	// grown over kernel versions but this prefix has been stable since it was introduced.
+	tcpInfoLostOff    = 32
+	tcpInfoRetransOff = 36
+	tcpInfoRttOff     = 68
+	tcpInfoSndCwndOff = 80
+	tcpInfoMinLen     = 84
+)
+
+type tcpSocketSample struct {
+	rttUs   uint32
+	sndCwnd uint32
+	retrans uint32
+	lost    uint32
+}
+
+func nlmAlign(l int) int {
+	return (l + 3) &^ 3
+}
+
+// buildInetDiagReq builds an NLM_F_REQUEST|NLM_F_DUMP SOCK_DIAG_BY_FAMILY message asking for
+// every TCP socket of the given family with its INET_DIAG_INFO (tcp_info) attribute attached.
+func buildInetDiagReq(family uint8) []byte {
+	buf := make([]byte, unix.SizeofNlMsghdr+inetDiagReqV2Len)
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(buf)))
+	binary.LittleEndian.PutUint16(buf[4:6], sockDiagByFamily)
+	binary.LittleEndian.PutUint16(buf[6:8], unix.NLM_F_REQUEST|unix.NLM_F_DUMP)
+	binary.LittleEndian.PutUint32(buf[8:12], 1) // sequence number
+
+	body := buf[unix.SizeofNlMsghdr:]
+	body[0] = family
+	body[1] = unix.IPPROTO_TCP
+	body[2] = inetDiagInfoExt
+	binary.LittleEndian.PutUint32(body[4:8], inetDiagAllStates)
+	// the inet_diag_sockid that follows is left zeroed, matching every socket.
+	return buf
+}
+
+type netlinkMsg struct {
+	msgType uint16
+	data    []byte
+}
+
+func parseNetlinkMessages(buf []byte) []netlinkMsg {
+	var msgs []netlinkMsg
+	for len(buf) >= unix.SizeofNlMsghdr {
+		length := int(binary.LittleEndian.Uint32(buf[0:4]))
+		msgType := binary.LittleEndian.Uint16(buf[4:6])
+		if length < unix.SizeofNlMsghdr || length > len(buf) {
+			break
+		}
+		msgs = append(msgs, netlinkMsg{msgType: msgType, data: buf[unix.SizeofNlMsghdr:length]})
+		buf = buf[nlmAlign(length):]
+	}
+	return msgs
+}
+
+// parseInetDiagMsg scans the rtattrs following a fixed-size inet_diag_msg for INET_DIAG_INFO
+// and decodes the handful of tcp_info fields this collector cares about.
+func parseInetDiagMsg(data []byte) (tcpSocketSample, bool) {
+	if len(data) < inetDiagMsgLen {
+		return tcpSocketSample{}, false
+	}
+	attrs := data[inetDiagMsgLen:]
+	for len(attrs) >= 4 {
+		rtaLen := int(binary.LittleEndian.Uint16(attrs[0:2]))
+		rtaType := binary.LittleEndian.Uint16(attrs[2:4])
+		if rtaLen < 4 || rtaLen > len(attrs) {
+			break
+		}
+		payload := attrs[4:rtaLen]
+		if rtaType == inetDiagInfoAttr && len(payload) >= tcpInfoMinLen {
+			return tcpSocketSample{
+				lost:    binary.LittleEndian.Uint32(payload[tcpInfoLostOff : tcpInfoLostOff+4]),
+				retrans: binary.LittleEndian.Uint32(payload[tcpInfoRetransOff : tcpInfoRetransOff+4]),
+				rttUs:   binary.LittleEndian.Uint32(payload[tcpInfoRttOff : tcpInfoRttOff+4]),
+				sndCwnd: binary.LittleEndian.Uint32(payload[tcpInfoSndCwndOff : tcpInfoSndCwndOff+4]),
+			}, true
+		}
+		attrs = attrs[nlmAlign(rtaLen):]
+	}
+	return tcpSocketSample{}, false
+}
+
+// queryTCPSocketDiag dumps every TCP socket of the given address family via NETLINK_SOCK_DIAG.
+func queryTCPSocketDiag(family uint8) ([]tcpSocketSample, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW, unix.NETLINK_SOCK_DIAG)
+	if err != nil {
+		return nil, err
+	}
+	defer unix.Close(fd)
+
+	if err := unix.Bind(fd, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		return nil, err
+	}
+	if err := unix.Sendto(fd, buildInetDiagReq(family), 0, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		return nil, err
+	}
+
+	var samples []tcpSocketSample
+	buf := make([]byte, 32*1024)
+	for {
+		n, _, err := unix.Recvfrom(fd, buf, 0)
+		if err != nil {
+			return nil, err
+		}
+		done := false
+		for _, msg := range parseNetlinkMessages(buf[:n]) {
+			switch msg.msgType {
+			case unix.NLMSG_DONE:
+				done = true
+			case unix.NLMSG_ERROR:
+				return nil, errors.New("netlink sock_diag returned an error response")
+			default:
+				if sample, ok := parseInetDiagMsg(msg.data); ok {
+					samples = append(samples, sample)
+				}
+			}
+		}
+		if done {
+			break
+		}
+	}
+	return samples, nil
+}
+
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(p * float64(len(sorted)-1))
+	return sorted[idx]
+}
+
+// collectTCPSocketDiagMetrics enriches the /proc/net/tcp state tally with per-socket RTT
+// percentiles and tcp_retrans_total, the sum over all sockets of tcpi_retrans (each socket's
+// retransmitted segments not yet acknowledged), falling back to doing nothing if the netlink
+// query fails (e.g. inside a restricted/unprivileged network namespace).
+func (r *InputSystem) collectTCPSocketDiagMetrics(collector ilogtail.Collector) {
+	var samples []tcpSocketSample
+	for _, family := range []uint8{unix.AF_INET, unix.AF_INET6} {
+		s, err := queryTCPSocketDiag(family)
+		if err != nil {
+			continue
+		}
+		samples = append(samples, s...)
+	}
+	if len(samples) == 0 {
+		return
+	}
+
+	rtts := make([]float64, 0, len(samples))
+	var totalRetrans uint64
+	for _, s := range samples {
+		rtts = append(rtts, float64(s.rttUs))
+		totalRetrans += uint64(s.retrans)
+	}
+	sort.Float64s(rtts)
+	r.addMetric(collector, "tcp_rtt_us_p50", r.commonLabelsStr, percentile(rtts, 0.50))
+	r.addMetric(collector, "tcp_rtt_us_p90", r.commonLabelsStr, percentile(rtts, 0.90))
+	r.addMetric(collector, "tcp_rtt_us_p99", r.commonLabelsStr, percentile(rtts, 0.99))
+	r.addMetric(collector, "tcp_retrans_total", r.commonLabelsStr, float64(totalRetrans))
+}
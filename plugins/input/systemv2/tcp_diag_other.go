@@ -0,0 +1,26 @@
+// Copyright 2021 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux
+// +build !linux
+
+package systemv2
+
+import "github.com/alibaba/ilogtail"
+
+// collectTCPSocketDiagMetrics is only implemented on Linux, where NETLINK_SOCK_DIAG exists.
+// Elsewhere CollectTCPConnStats still reports per-state connection counts from /proc-style
+// parsing where available, just without the netlink-derived RTT/retransmit enrichment.
+func (r *InputSystem) collectTCPSocketDiagMetrics(collector ilogtail.Collector) {
+}